@@ -0,0 +1,156 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// CircuitState describes the state of a per-member circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed means the member is healthy and receiving requests.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means the member has exceeded its failure threshold and
+	// is being health-checked on a backoff schedule before it is retried.
+	CircuitOpen
+	// CircuitHalfOpen means the member is currently being probed to see if
+	// it has recovered.
+	CircuitHalfOpen
+)
+
+// String returns a human readable representation of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "Open"
+	case CircuitHalfOpen:
+		return "HalfOpen"
+	default:
+		return "Closed"
+	}
+}
+
+// CircuitBreakerCallback is invoked whenever a member's circuit breaker
+// transitions between states, allowing operators to hook in logging or
+// metrics. Set it on Config.CircuitBreakerCallback; NewClient threads it
+// into every member's circuitBreaker via clusterOptions.
+type CircuitBreakerCallback func(endpoint string, from, to CircuitState)
+
+const (
+	defaultFailureThreshold = 1
+	defaultBackoffBase      = 500 * time.Millisecond
+	defaultBackoffMax       = 30 * time.Second
+)
+
+// circuitBreaker tracks consecutive failures for a single member and
+// computes the backoff to wait before the next health-check probe.
+type circuitBreaker struct {
+	sync.Mutex
+	state            CircuitState
+	failures         int
+	failureThreshold int
+	backoffBase      time.Duration
+	backoffMax       time.Duration
+	backoffCurrent   time.Duration
+}
+
+// newCircuitBreaker builds a circuitBreaker using the given threshold and
+// backoff bounds, falling back to package defaults for zero values.
+func newCircuitBreaker(failureThreshold int, backoffBase, backoffMax time.Duration) *circuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = defaultFailureThreshold
+	}
+	if backoffBase <= 0 {
+		backoffBase = defaultBackoffBase
+	}
+	if backoffMax <= 0 {
+		backoffMax = defaultBackoffMax
+	}
+
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		backoffBase:      backoffBase,
+		backoffMax:       backoffMax,
+	}
+}
+
+// recordFailure registers a failed request and returns the breaker's state
+// before and after, so callers can tell whether this failure tripped the
+// breaker open.
+func (b *circuitBreaker) recordFailure() (from, to CircuitState) {
+	b.Lock()
+	defer b.Unlock()
+	from = b.state
+	b.failures++
+	if b.state == CircuitClosed && b.failures >= b.failureThreshold {
+		b.state = CircuitOpen
+		b.backoffCurrent = b.backoffBase
+	}
+	return from, b.state
+}
+
+// recordSuccess resets the breaker back to closed and returns the state
+// before and after, so callers can tell whether the member just recovered.
+func (b *circuitBreaker) recordSuccess() (from, to CircuitState) {
+	b.Lock()
+	defer b.Unlock()
+	from = b.state
+	b.failures = 0
+	b.state = CircuitClosed
+	b.backoffCurrent = 0
+	return from, b.state
+}
+
+// nextBackoff returns the next probe interval, doubling the current
+// backoff up to backoffMax and adding up to 20% jitter. It does not change
+// the breaker's state.
+func (b *circuitBreaker) nextBackoff() time.Duration {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.backoffCurrent == 0 {
+		b.backoffCurrent = b.backoffBase
+	}
+	interval := b.backoffCurrent
+
+	b.backoffCurrent *= 2
+	if b.backoffCurrent > b.backoffMax {
+		b.backoffCurrent = b.backoffMax
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(interval)/5 + 1))
+	return interval + jitter
+}
+
+// currentState returns the breaker's current state.
+func (b *circuitBreaker) currentState() CircuitState {
+	b.Lock()
+	defer b.Unlock()
+	return b.state
+}
+
+// setState forces the breaker into the given state, e.g. CircuitHalfOpen
+// while a probe is in flight.
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.Lock()
+	defer b.Unlock()
+	b.state = state
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerOpensAtThreshold(t *testing.T) {
+	b := newCircuitBreaker(3, 10*time.Millisecond, time.Second)
+
+	from, to := b.recordFailure()
+	assert.Equal(t, CircuitClosed, from)
+	assert.Equal(t, CircuitClosed, to)
+
+	from, to = b.recordFailure()
+	assert.Equal(t, CircuitClosed, to)
+
+	from, to = b.recordFailure()
+	assert.Equal(t, CircuitClosed, from)
+	assert.Equal(t, CircuitOpen, to)
+}
+
+func TestCircuitBreakerRecordSuccessResets(t *testing.T) {
+	b := newCircuitBreaker(1, 10*time.Millisecond, time.Second)
+
+	_, to := b.recordFailure()
+	assert.Equal(t, CircuitOpen, to)
+
+	from, to := b.recordSuccess()
+	assert.Equal(t, CircuitOpen, from)
+	assert.Equal(t, CircuitClosed, to)
+
+	// Backoff should also have reset, so the next failure starts at base again.
+	b.recordFailure()
+	interval := b.nextBackoff()
+	assert.True(t, interval >= 10*time.Millisecond && interval < 13*time.Millisecond)
+}
+
+func TestCircuitBreakerBackoffDoublesUpToMax(t *testing.T) {
+	b := newCircuitBreaker(1, 10*time.Millisecond, 35*time.Millisecond)
+
+	first := b.nextBackoff()
+	second := b.nextBackoff()
+	third := b.nextBackoff()
+	fourth := b.nextBackoff()
+
+	assert.True(t, first >= 10*time.Millisecond && first < 13*time.Millisecond)
+	assert.True(t, second >= 20*time.Millisecond && second < 25*time.Millisecond)
+	assert.True(t, third >= 35*time.Millisecond && third < 43*time.Millisecond)
+	assert.True(t, fourth >= 35*time.Millisecond && fourth < 43*time.Millisecond)
+}
+
+func TestCircuitStateString(t *testing.T) {
+	assert.Equal(t, "Closed", CircuitClosed.String())
+	assert.Equal(t, "Open", CircuitOpen.String())
+	assert.Equal(t, "HalfOpen", CircuitHalfOpen.String())
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrMarathonDown is returned whenever the cluster has no active member to
+// send a request to.
+var ErrMarathonDown = errors.New("no active marathon endpoints")
+
+// defaultDCOSPath is appended to any endpoint that doesn't already specify a
+// path when Config.DCOSToken is set.
+const defaultDCOSPath = "/service/marathon"
+
+var (
+	defaultHTTPClient      = &http.Client{Timeout: 10 * time.Second}
+	defaultHTTPSSEClient   = &http.Client{}
+	defaultPollingWaitTime = 500 * time.Millisecond
+)
+
+// Config is the configuration used to create a new Marathon client.
+type Config struct {
+	// URL is the Marathon endpoint(s), comma-separated for an HA cluster,
+	// e.g. "http://10.0.0.1:8080,http://10.0.0.2:8080".
+	URL string
+	// HTTPClient is used for all non-SSE requests; defaults to
+	// defaultHTTPClient.
+	HTTPClient *http.Client
+	// HTTPSSEClient is used for SSE event stream connections; defaults to
+	// defaultHTTPSSEClient. Must not set a Timeout, since it would cut off
+	// a long-lived stream.
+	HTTPSSEClient *http.Client
+	// HTTPBasicAuthUser and HTTPBasicPassword, if set, are sent as HTTP
+	// basic auth on every request.
+	HTTPBasicAuthUser string
+	HTTPBasicPassword string
+	// DCOSToken, if set, is sent as a DCOS ACS token and marks the cluster
+	// endpoints as being behind a DCOS Admin Router, which changes how the
+	// default path on each endpoint is derived (see defaultDCOSPath).
+	DCOSToken string
+	// PollingWaitTime is the interval used by callers polling for eventual
+	// state (e.g. deployment completion); defaults to defaultPollingWaitTime.
+	PollingWaitTime time.Duration
+
+	// LoadBalancingStrategy picks a built-in MemberSelector by name; ignored
+	// if MemberSelector is set. Defaults to FirstUp.
+	LoadBalancingStrategy LoadBalancingStrategy
+	// MemberSelector, if set, overrides LoadBalancingStrategy with a custom
+	// member selection policy.
+	MemberSelector MemberSelector
+	// FailureThreshold is the number of consecutive failures a member must
+	// accrue before its circuit breaker opens and it's pulled out of
+	// rotation. Defaults to defaultFailureThreshold.
+	FailureThreshold int
+	// BackoffBase and BackoffMax bound the exponential backoff used between
+	// health-check probes of an open circuit. Default to defaultBackoffBase
+	// and defaultBackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// CircuitBreakerCallback, if set, is invoked on every per-member circuit
+	// breaker state transition.
+	CircuitBreakerCallback CircuitBreakerCallback
+	// RetryPolicy governs how a request is retried against the current
+	// member before failing over to the next healthy one. Defaults to
+	// DefaultRetryPolicy.
+	RetryPolicy RetryPolicy
+	// Logger receives structured health-check and retry lifecycle events.
+	// Defaults to a no-op logger.
+	Logger Logger
+	// MetricsCollector receives request, health-check and member status
+	// lifecycle events. Defaults to a no-op collector.
+	MetricsCollector MetricsCollector
+}
+
+// NewDefaultConfig returns a Config with every optional field defaulted; set
+// URL (and any overrides) on the returned value before passing it to
+// NewClient.
+func NewDefaultConfig() Config {
+	return Config{
+		HTTPClient:      defaultHTTPClient,
+		HTTPSSEClient:   defaultHTTPSSEClient,
+		PollingWaitTime: defaultPollingWaitTime,
+	}
+}
+
+// Marathon is the client interface for talking to a Marathon cluster.
+type Marathon interface {
+	// Ping checks that at least one cluster member is reachable.
+	Ping() (bool, error)
+	// PingContext is the context-aware variant of Ping.
+	PingContext(ctx context.Context) (bool, error)
+	// GetMarathonURL returns the configured cluster URL.
+	GetMarathonURL() string
+	// Applications lists the deployed applications matching v (e.g.
+	// "embed" or "id" filters); a nil v lists everything.
+	Applications(v url.Values) (*Applications, error)
+	// ApplicationsContext is the context-aware variant of Applications.
+	ApplicationsContext(ctx context.Context, v url.Values) (*Applications, error)
+	// ClusterStatus returns the current circuit breaker state of every
+	// cluster member, keyed by endpoint.
+	ClusterStatus() map[string]CircuitState
+	// Stop terminates background health-checking. Safe to call more than
+	// once.
+	Stop()
+}
+
+// marathonClient is the concrete Marathon implementation.
+type marathonClient struct {
+	config     Config
+	hosts      *cluster
+	httpClient *httpClient
+}
+
+// NewClient creates a new Marathon client from config.
+func NewClient(config Config) (Marathon, error) {
+	if config.HTTPClient == nil {
+		config.HTTPClient = defaultHTTPClient
+	}
+	if config.HTTPSSEClient == nil {
+		config.HTTPSSEClient = defaultHTTPSSEClient
+	}
+	if config.HTTPSSEClient.Timeout != 0 {
+		return nil, fmt.Errorf("marathon: HTTPSSEClient must not set a Timeout (got %s), it would cut off the event stream", config.HTTPSSEClient.Timeout)
+	}
+	if config.PollingWaitTime == 0 {
+		config.PollingWaitTime = defaultPollingWaitTime
+	}
+
+	hc := newHTTPClient(config)
+
+	selector := config.MemberSelector
+	if selector == nil {
+		selector = newMemberSelector(config.LoadBalancingStrategy)
+	}
+
+	hosts, err := newCluster(hc, config.URL, config.DCOSToken != "", clusterOptions{
+		selector:               selector,
+		failureThreshold:       config.FailureThreshold,
+		backoffBase:            config.BackoffBase,
+		backoffMax:             config.BackoffMax,
+		circuitBreakerCallback: config.CircuitBreakerCallback,
+		retryPolicy:            config.RetryPolicy,
+		metrics:                config.MetricsCollector,
+		logger:                 config.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &marathonClient{config: config, hosts: hosts, httpClient: hc}, nil
+}
+
+// GetMarathonURL returns the configured cluster URL.
+func (r *marathonClient) GetMarathonURL() string {
+	return r.config.URL
+}
+
+// Ping checks that at least one cluster member is reachable.
+func (r *marathonClient) Ping() (bool, error) {
+	return r.PingContext(context.Background())
+}
+
+// PingContext is the context-aware variant of Ping.
+func (r *marathonClient) PingContext(ctx context.Context) (bool, error) {
+	res, err := r.hosts.Do(ctx, http.MethodGet, "ping", nil)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	return res.StatusCode == http.StatusOK, nil
+}
+
+// Applications lists the deployed applications matching v; a nil v lists
+// everything.
+func (r *marathonClient) Applications(v url.Values) (*Applications, error) {
+	return r.ApplicationsContext(context.Background(), v)
+}
+
+// ApplicationsContext is the context-aware variant of Applications.
+func (r *marathonClient) ApplicationsContext(ctx context.Context, v url.Values) (*Applications, error) {
+	path := "v2/apps"
+	if v != nil {
+		path += "?" + v.Encode()
+	}
+
+	res, err := r.hosts.Do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("marathon: unexpected status %d fetching applications: %s", res.StatusCode, oneLogLine(body))
+	}
+
+	apps := &Applications{}
+	if err := json.Unmarshal(body, apps); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// ClusterStatus returns the current circuit breaker state of every cluster
+// member, keyed by endpoint.
+func (r *marathonClient) ClusterStatus() map[string]CircuitState {
+	return r.hosts.ClusterStatus()
+}
+
+// Stop terminates background health-checking. Safe to call more than once.
+func (r *marathonClient) Stop() {
+	r.hosts.Stop()
+}
+
+// Applications is the response body of a GET /v2/apps request.
+type Applications struct {
+	Apps []Application `json:"apps"`
+}
+
+// Application is a single Marathon application definition, as embedded in
+// an Applications listing.
+type Application struct {
+	ID string `json:"id"`
+}
+
+// newRequestError wraps a failure to build an *http.Request (e.g. an
+// unparsable path), as distinct from a failure of the request itself once
+// issued.
+type newRequestError struct {
+	Err error
+}
+
+func (e newRequestError) Error() string {
+	return fmt.Sprintf("marathon: failed to build request: %s", e.Err)
+}
+
+// newInvalidEndpointError reports a malformed Marathon endpoint URL.
+func newInvalidEndpointError(format string, args ...interface{}) error {
+	return fmt.Errorf("marathon: invalid endpoint: "+format, args...)
+}
+
+// httpClient builds and issues requests against a single Marathon endpoint,
+// applying the configured authentication on every request.
+type httpClient struct {
+	config Config
+	client *http.Client
+}
+
+// newHTTPClient returns an httpClient that issues requests with config's
+// HTTPClient and authentication settings.
+func newHTTPClient(config Config) *httpClient {
+	return &httpClient{config: config, client: config.HTTPClient}
+}
+
+// buildMarathonRequest builds a request for path against endpoint, applying
+// basic auth or a DCOS token as configured.
+func (h *httpClient) buildMarathonRequest(method, endpoint, path string, body io.Reader) (*http.Request, error) {
+	u := strings.TrimRight(endpoint, "/") + "/" + strings.TrimLeft(path, "/")
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, newRequestError{Err: err}
+	}
+	if h.config.HTTPBasicAuthUser != "" {
+		req.SetBasicAuth(h.config.HTTPBasicAuthUser, h.config.HTTPBasicPassword)
+	}
+	if h.config.DCOSToken != "" {
+		req.Header.Set("Authorization", "token="+h.config.DCOSToken)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Do issues req using the underlying *http.Client.
+func (h *httpClient) Do(req *http.Request) (*http.Response, error) {
+	return h.client.Do(req)
+}
+
+// oneLogLine flattens a (possibly multi-line) response body into a single
+// line suitable for a structured log field: each line is trimmed of
+// surrounding whitespace and rejoined with a literal "\n " marker instead of
+// an actual newline. A leading blank line (as produced by a raw string
+// literal starting right after the opening backtick) is dropped; trailing
+// ones are kept, since they're part of the body.
+func oneLogLine(in []byte) []byte {
+	var out [][]byte
+	started := false
+	for _, line := range bytes.Split(in, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if !started {
+			if len(trimmed) == 0 {
+				continue
+			}
+			started = true
+		}
+		out = append(out, trimmed)
+	}
+	return bytes.Join(out, []byte(`\n `))
+}
@@ -278,31 +278,63 @@ func TestAPIRequestDCOS(t *testing.T) {
 
 func TestStop(t *testing.T) {
 	var reqCount uint32
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	var pinged uint32
+	probing := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddUint32(&reqCount, 1)
+		if atomic.LoadUint32(&pinged) == 0 {
+			// Ping's own attempts fail straight away, so only the
+			// health-check probes that follow it are signaled and held open.
+			http.Error(w, "I'm down", 503)
+			return
+		}
+		select {
+		case probing <- struct{}{}:
+		default:
+		}
+		// Block until the probe's context is canceled (or this handler
+		// times out), so the test can prove Stop() aborts an in-flight
+		// health check immediately rather than waiting for it to return.
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
 		http.Error(w, "I'm down", 503)
 	}))
 	defer ts.Close()
 
 	client, err := NewClient(Config{URL: ts.URL})
 	require.NoError(t, err)
-	client.(*marathonClient).hosts.healthCheckInterval = 50 * time.Millisecond
+	for _, m := range client.(*marathonClient).hosts.members {
+		m.breaker.backoffBase = 10 * time.Millisecond
+		m.breaker.backoffMax = 10 * time.Millisecond
+	}
 
 	_, err = client.Ping()
 	require.Equal(t, ErrMarathonDown, err)
+	atomic.StoreUint32(&pinged, 1)
 
-	// Expect some health checks to fail.
-	time.Sleep(150 * time.Millisecond)
-	count := int(atomic.LoadUint32(&reqCount))
-	require.True(t, count > 0, "expected non-zero request count")
-
-	// Stop all health check goroutines.
-	// Should be okay to call the method multiple times.
-	client.Stop()
-	client.Stop()
+	select {
+	case <-probing:
+	case <-time.After(time.Second):
+		t.Fatal("expected a health-check probe to start")
+	}
 
-	// Wait for all health checks to terminate.
-	time.Sleep(100 * time.Millisecond)
+	// Stop must cancel the in-flight probe's context and return promptly --
+	// well before the handler's 5s delay -- instead of waiting for it to
+	// complete. Should also be okay to call the method multiple times.
+	stopped := make(chan struct{})
+	go func() {
+		client.Stop()
+		client.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return promptly; in-flight probe was not canceled")
+	}
 
 	// Reset request counter.
 	atomic.StoreUint32(&reqCount, 0)
@@ -310,6 +342,6 @@ func TestStop(t *testing.T) {
 	// Wait another small period, not expecting any further health checks to
 	// fire.
 	time.Sleep(100 * time.Millisecond)
-	count = int(atomic.LoadUint32(&reqCount))
+	count := int(atomic.LoadUint32(&reqCount))
 	assert.Equal(t, 0, count, "expected zero request count")
 }
@@ -17,21 +17,24 @@ limitations under the License.
 package marathon
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
 	"time"
 )
 
-const (
-	memberStatusUp   = 0
-	memberStatusDown = 1
-)
-
 // the status of a member node
 type memberStatus int
 
+const (
+	memberStatusUp memberStatus = iota
+	memberStatusDown
+)
+
 // cluster is a collection of marathon nodes
 type cluster struct {
 	sync.RWMutex
@@ -39,17 +42,34 @@ type cluster struct {
 	members []*member
 	// the marathon HTTP client to ensure consistency in requests
 	client *httpClient
-	// healthCheckInterval is the interval by which we probe down nodes for
-	// availability again.
-	healthCheckInterval time.Duration
 	// done is a channel signaling to all pending health-checking routines
 	// that it's time to shut down.
 	done chan struct{}
+	// ctx is derived from the caller's context (or context.Background())
+	// and is canceled by Stop(), so health-check probes in flight are
+	// aborted immediately instead of running to completion.
+	ctx context.Context
+	// cancel cancels ctx; invoked once by Stop().
+	cancel context.CancelFunc
 	// isDone is used to guarantee thread-safety when calling Stop().
 	isDone bool
 	// healthCheckWg is a sync.Workgroup sychronizing the successful
 	// termination of all pending health-check routines.
 	healthCheckWg sync.WaitGroup
+	// selector decides which active member getMember() hands out next.
+	selector MemberSelector
+	// circuitBreakerCallback, if set, is invoked on every per-member circuit
+	// breaker state transition.
+	circuitBreakerCallback CircuitBreakerCallback
+	// retryPolicy governs how Do retries a request against the current
+	// member before failing over to the next healthy one.
+	retryPolicy RetryPolicy
+	// metrics receives request, health-check and member status lifecycle
+	// events; defaults to a no-op collector.
+	metrics MetricsCollector
+	// logger emits structured events for health-check state changes;
+	// defaults to a no-op logger.
+	logger Logger
 }
 
 // member represents an individual endpoint
@@ -58,10 +78,38 @@ type member struct {
 	endpoint string
 	// the status of the host
 	status memberStatus
+	// lastUsed records the last time this member was handed out by
+	// getMember(); consulted by the LeastRecentlyUsed selector.
+	lastUsed time.Time
+	// breaker tracks consecutive failures and the backoff schedule used to
+	// decide when to re-probe this member once it's marked down.
+	breaker *circuitBreaker
+}
+
+// clusterOptions bundles the tunables that newCluster needs beyond the
+// Marathon endpoint list itself. Grouping them keeps newCluster's signature
+// stable as more cluster-level behavior (selection, circuit breaking, ...)
+// becomes configurable.
+type clusterOptions struct {
+	selector MemberSelector
+	// failureThreshold, backoffBase and backoffMax seed every member's
+	// circuitBreaker at construction time; the cluster itself doesn't keep
+	// a live copy since each breaker already owns its own.
+	failureThreshold       int
+	backoffBase            time.Duration
+	backoffMax             time.Duration
+	circuitBreakerCallback CircuitBreakerCallback
+	retryPolicy            RetryPolicy
+	metrics                MetricsCollector
+	logger                 Logger
+	// ctx is the parent context health-check probes are derived from. A nil
+	// ctx defaults to context.Background().
+	ctx context.Context
 }
 
-// newCluster returns a new marathon cluster
-func newCluster(client *httpClient, marathonURL string, isDCOS bool) (*cluster, error) {
+// newCluster returns a new marathon cluster. A nil opts.selector defaults to
+// the historical first-active-member behavior.
+func newCluster(client *httpClient, marathonURL string, isDCOS bool, opts clusterOptions) (*cluster, error) {
 	// step: extract and basic validate the endpoints
 	var members []*member
 	var defaultProto string
@@ -101,19 +149,51 @@ func newCluster(client *httpClient, marathonURL string, isDCOS bool) (*cluster,
 		}
 
 		// step: create a new node for this endpoint
-		members = append(members, &member{endpoint: u.String()})
+		members = append(members, &member{
+			endpoint: u.String(),
+			breaker:  newCircuitBreaker(opts.failureThreshold, opts.backoffBase, opts.backoffMax),
+		})
 	}
 
+	selector := opts.selector
+	if selector == nil {
+		selector = &firstUpSelector{}
+	}
+	metrics := opts.metrics
+	if metrics == nil {
+		metrics = noopMetricsCollector{}
+	}
+	logger := opts.logger
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	retryPolicy := opts.retryPolicy
+	if retryPolicy.MaxAttempts == 0 {
+		retryPolicy = DefaultRetryPolicy()
+	}
+	parent := opts.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
 	return &cluster{
-		client:              client,
-		members:             members,
-		healthCheckInterval: 5 * time.Second,
-		done:                make(chan struct{}),
+		client:                 client,
+		members:                members,
+		done:                   make(chan struct{}),
+		ctx:                    ctx,
+		cancel:                 cancel,
+		selector:               selector,
+		circuitBreakerCallback: opts.circuitBreakerCallback,
+		retryPolicy:            retryPolicy,
+		metrics:                metrics,
+		logger:                 logger,
 	}, nil
 }
 
 // Stop gracefully terminates the cluster. It returns once all health-checking
-// goroutines have finished.
+// goroutines have finished. Canceling ctx aborts any health-check probe
+// currently in flight rather than waiting for it to complete.
 func (c *cluster) Stop() {
 	c.Lock()
 	defer c.Unlock()
@@ -122,23 +202,29 @@ func (c *cluster) Stop() {
 	}
 	c.isDone = true
 	close(c.done)
+	c.cancel()
 	c.healthCheckWg.Wait()
 }
 
-// retrieve the current member, i.e. the current endpoint in use
+// retrieve the current member, i.e. the endpoint to use for the next
+// request, as chosen by the cluster's MemberSelector.
 func (c *cluster) getMember() (string, error) {
-	c.RLock()
-	defer c.RUnlock()
+	c.Lock()
+	defer c.Unlock()
+	var active []*member
 	for _, n := range c.members {
 		if n.status == memberStatusUp {
-			return n.endpoint, nil
+			active = append(active, n)
 		}
 	}
 
-	return "", ErrMarathonDown
+	return c.selector.Select(active)
 }
 
-// markDown marks down the current endpoint
+// markDown registers a failed request against endpoint's circuit breaker.
+// The node is only pulled out of rotation -- and a health-check probe
+// started -- once its failure threshold has been crossed; transient,
+// isolated failures leave it in place.
 func (c *cluster) markDown(endpoint string) {
 	c.Lock()
 	defer c.Unlock()
@@ -146,7 +232,16 @@ func (c *cluster) markDown(endpoint string) {
 		// step: check if this is the node and it's marked as up - The double  checking on the
 		// nodes status ensures the multiple calls don't create multiple checks
 		if n.status == memberStatusUp && n.endpoint == endpoint {
+			from, to := n.breaker.recordFailure()
+			c.notifyCircuitTransition(n.endpoint, from, to)
+			if to != CircuitOpen {
+				// step: still under the failure threshold, leave it up
+				c.logger.Debug("member failure below threshold", "endpoint", n.endpoint)
+				return
+			}
 			n.status = memberStatusDown
+			c.metrics.MemberStatusChanged(n.endpoint, false)
+			c.logger.Warn("member marked down", "endpoint", n.endpoint)
 			c.healthCheckWg.Add(1)
 			go func() {
 				defer c.healthCheckWg.Done()
@@ -157,27 +252,184 @@ func (c *cluster) markDown(endpoint string) {
 	}
 }
 
-// healthCheckNode performs a health check on the node and when active updates the status
+// notifyCircuitTransition invokes the configured CircuitBreakerCallback, if
+// any, when a member's circuit breaker changes state.
+func (c *cluster) notifyCircuitTransition(endpoint string, from, to CircuitState) {
+	if from == to || c.circuitBreakerCallback == nil {
+		return
+	}
+	c.circuitBreakerCallback(endpoint, from, to)
+}
+
+// ClusterStatus returns the current circuit breaker state of every member,
+// keyed by endpoint.
+func (c *cluster) ClusterStatus() map[string]CircuitState {
+	c.RLock()
+	defer c.RUnlock()
+	status := make(map[string]CircuitState, len(c.members))
+	for _, n := range c.members {
+		status[n.endpoint] = n.breaker.currentState()
+	}
+	return status
+}
+
+// Do issues method/path against a healthy cluster member, retrying it per
+// the configured RetryPolicy -- honoring any Retry-After header on a
+// 429/503 response -- before giving up on that member. Once every attempt
+// against the current member is exhausted with a failure response (a
+// transport error, a 429, or a 5xx), the member is marked down via markDown
+// and the request is transparently re-issued against the next healthy
+// member, failing over at most as many times as the cluster has members; a
+// member still under its failure threshold stays active and can be picked
+// again. If no active member is left once the last try fails, Do returns
+// ErrMarathonDown. ctx bounds the entire call, including every attempt and
+// every member tried.
+func (c *cluster) Do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	return c.dispatch(ctx, method, path, body, c.size())
+}
+
+// dispatch is Do's failover step; membersLeft bounds how many members are
+// tried so a cluster where every member is failing doesn't recurse forever.
+func (c *cluster) dispatch(ctx context.Context, method, path string, body []byte, membersLeft int) (*http.Response, error) {
+	endpoint, err := c.getMember()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.doWithRetry(ctx, endpoint, method, path, body)
+	if !isFailureResponse(res, err) {
+		// A success clears the member's failure count, so only consecutive
+		// failures count towards its threshold.
+		c.Lock()
+		for _, n := range c.members {
+			if n.endpoint == endpoint && n.status == memberStatusUp {
+				n.breaker.recordSuccess()
+			}
+		}
+		c.Unlock()
+		return res, nil
+	}
+
+	c.markDown(endpoint)
+	if membersLeft <= 1 {
+		if len(c.activeMembers()) == 0 {
+			if res != nil {
+				res.Body.Close()
+			}
+			return nil, ErrMarathonDown
+		}
+		return res, err
+	}
+	if res != nil {
+		res.Body.Close()
+	}
+	return c.dispatch(ctx, method, path, body, membersLeft-1)
+}
+
+// isFailureResponse reports whether res/err represents a member-level
+// failure that should trip markDown/failover -- a transport error, a missing
+// response, a 429, or a 5xx -- independent of whether RetryPolicy chose to
+// retry it (a policy may legitimately decline to retry a failing response,
+// e.g. a non-idempotent method, without that making the response a success).
+func isFailureResponse(res *http.Response, err error) bool {
+	if err != nil || res == nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError
+}
+
+// doWithRetry issues method/path against endpoint, retrying according to
+// c.retryPolicy until its ShouldRetry predicate declines, attempts are
+// exhausted, or ctx is canceled.
+func (c *cluster) doWithRetry(ctx context.Context, endpoint, method, path string, body []byte) (*http.Response, error) {
+	policy := c.retryPolicy
+	shouldRetry := policy.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = defaultShouldRetry
+	}
+	attempts := policy.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		req, err := c.client.buildMarathonRequest(method, endpoint, path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.WithContext(ctx)
+		started := time.Now()
+		res, err := c.client.Do(req)
+		statusCode := 0
+		if res != nil {
+			statusCode = res.StatusCode
+		}
+		c.metrics.RequestCompleted(endpoint, method, path, statusCode, time.Since(started))
+		lastErr = err
+
+		if attempt == attempts || !shouldRetry(req, res, err) {
+			return res, err
+		}
+
+		wait := policy.backoff(attempt)
+		if res != nil {
+			if after, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+				wait = after
+			}
+			// This attempt is being discarded in favor of a retry; its
+			// response body must be drained/closed now or the connection
+			// leaks.
+			res.Body.Close()
+		}
+
+		c.metrics.RequestRetried(endpoint, method, path, attempt)
+		c.logger.Debug("retrying request", "endpoint", endpoint, "method", method, "path", path, "attempt", attempt)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return nil, lastErr
+}
+
+// healthCheckNode performs a health check on the node and when active updates the status.
+// It probes on an exponential backoff (with jitter) that starts at the breaker's base
+// interval and doubles up to its cap, rather than a fixed poll interval, so a node that's
+// been down a while isn't hammered with probes.
 func (c *cluster) healthCheckNode(node *member) {
-	// step: wait for the node to become active ... we are assuming a /ping is enough here
-	ticker := time.NewTicker(c.healthCheckInterval)
-	defer ticker.Stop()
 	for {
+		timer := time.NewTimer(node.breaker.nextBackoff())
+
 		select {
 		case <-c.done:
+			timer.Stop()
 			return
-		case <-ticker.C:
+		case <-timer.C:
+			node.breaker.setState(CircuitHalfOpen)
+			c.notifyCircuitTransition(node.endpoint, CircuitOpen, CircuitHalfOpen)
 			req, err := c.client.buildMarathonRequest("GET", node.endpoint, "ping", nil)
 			if err == nil {
-				res, err := c.client.Do(req)
+				res, err := c.client.Do(req.WithContext(c.ctx))
 				if err == nil && res.StatusCode == 200 {
+					c.metrics.HealthCheckResult(node.endpoint, true)
 					// step: mark the node as active again
 					c.Lock()
 					node.status = memberStatusUp
 					c.Unlock()
-					break
+					c.metrics.MemberStatusChanged(node.endpoint, true)
+					c.logger.Info("member recovered", "endpoint", node.endpoint)
+					from, to := node.breaker.recordSuccess()
+					c.notifyCircuitTransition(node.endpoint, from, to)
+					return
 				}
 			}
+			c.metrics.HealthCheckResult(node.endpoint, false)
+			node.breaker.setState(CircuitOpen)
+			c.notifyCircuitTransition(node.endpoint, CircuitHalfOpen, CircuitOpen)
 		}
 	}
 }
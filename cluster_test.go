@@ -0,0 +1,44 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterStopCancelsContext(t *testing.T) {
+	c, err := newCluster(nil, "http://10.0.0.1:8080", false, clusterOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, c.ctx.Err())
+
+	c.Stop()
+
+	assert.Equal(t, context.Canceled, c.ctx.Err())
+}
+
+func TestNewClusterDerivesFromParentContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	c, err := newCluster(nil, "http://10.0.0.1:8080", false, clusterOptions{ctx: parent})
+	assert.NoError(t, err)
+
+	cancel()
+
+	assert.Equal(t, context.Canceled, c.ctx.Err())
+}
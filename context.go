@@ -0,0 +1,36 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+// This file tracks the context-cancellation behavior implemented so far:
+//
+//   - cluster derives a cancelable context (cluster.ctx) from the caller's
+//     context, or context.Background() if none is given, and cancels it once
+//     from Stop(). healthCheckNode's probe requests are issued with
+//     req.WithContext(cluster.ctx), so Stop() aborts any probe in flight
+//     immediately instead of letting it run to completion.
+//
+//   - cluster.Do takes its own per-call ctx, independent of cluster.ctx, and
+//     threads it through every retry attempt and every member tried during
+//     failover. Canceling that ctx aborts the in-flight attempt and unblocks
+//     the retry backoff immediately. The exported ...Context client methods
+//     (PingContext, ApplicationsContext) pass their caller-supplied ctx
+//     straight through to cluster.Do; the non-Context variants (Ping,
+//     Applications) are a thin wrapper around context.Background().
+//
+// Not covered: the cluster's SSE event stream has no implementation in this
+// package yet, so there's no subscription to cancel.
@@ -0,0 +1,158 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// LoadBalancingStrategy identifies one of the built-in MemberSelector
+// implementations, for picking a strategy by name without having to
+// construct a MemberSelector by hand. Set it on Config.LoadBalancingStrategy;
+// NewClient threads it into newCluster via newMemberSelector.
+type LoadBalancingStrategy int
+
+const (
+	// FirstUp always returns the first active member in cluster order. This
+	// matches the historical, pre-LoadBalancingStrategy behavior and remains
+	// the default so existing callers see no change in member selection.
+	FirstUp LoadBalancingStrategy = iota
+	// RoundRobin cycles through the active members on every call.
+	RoundRobin
+	// Random picks a uniformly random active member on every call.
+	Random
+	// LeastRecentlyUsed picks the active member that has gone the longest
+	// without being selected.
+	LeastRecentlyUsed
+)
+
+// MemberSelector picks which active member a request should be sent to.
+// The cluster calls Select while holding its own lock, so calls from one
+// cluster never overlap; leastRecentlyUsedSelector relies on this when it
+// updates member.lastUsed. A selector set on Config.MemberSelector, such as
+// LeaderAffinitySelector, overrides Config.LoadBalancingStrategy. Select
+// takes the package's unexported member type, so MemberSelector can only be
+// implemented inside this package.
+type MemberSelector interface {
+	// Select returns the endpoint to use for the next request, chosen from
+	// the supplied list of currently active members. It returns
+	// ErrMarathonDown if members is empty.
+	Select(members []*member) (string, error)
+}
+
+// newMemberSelector builds the built-in MemberSelector for the given
+// strategy. It is used by newCluster when no custom MemberSelector is
+// supplied via Config.
+func newMemberSelector(strategy LoadBalancingStrategy) MemberSelector {
+	switch strategy {
+	case RoundRobin:
+		return &roundRobinSelector{}
+	case Random:
+		return &randomSelector{}
+	case LeastRecentlyUsed:
+		return &leastRecentlyUsedSelector{}
+	default:
+		return &firstUpSelector{}
+	}
+}
+
+// firstUpSelector returns the first active member in cluster order.
+type firstUpSelector struct{}
+
+func (s *firstUpSelector) Select(members []*member) (string, error) {
+	if len(members) == 0 {
+		return "", ErrMarathonDown
+	}
+	return members[0].endpoint, nil
+}
+
+// roundRobinSelector cycles through the active members in order.
+type roundRobinSelector struct {
+	sync.Mutex
+	next int
+}
+
+func (s *roundRobinSelector) Select(members []*member) (string, error) {
+	if len(members) == 0 {
+		return "", ErrMarathonDown
+	}
+	s.Lock()
+	defer s.Unlock()
+	m := members[s.next%len(members)]
+	s.next++
+	return m.endpoint, nil
+}
+
+// randomSelector picks a uniformly random active member.
+type randomSelector struct{}
+
+func (s *randomSelector) Select(members []*member) (string, error) {
+	if len(members) == 0 {
+		return "", ErrMarathonDown
+	}
+	return members[rand.Intn(len(members))].endpoint, nil
+}
+
+// leastRecentlyUsedSelector picks the active member that was selected
+// longest ago (or never), tracked via member.lastUsed.
+type leastRecentlyUsedSelector struct{}
+
+func (s *leastRecentlyUsedSelector) Select(members []*member) (string, error) {
+	if len(members) == 0 {
+		return "", ErrMarathonDown
+	}
+	lru := members[0]
+	for _, m := range members[1:] {
+		if m.lastUsed.Before(lru.lastUsed) {
+			lru = m
+		}
+	}
+	lru.lastUsed = time.Now()
+	return lru.endpoint, nil
+}
+
+// LeaderAffinitySelector prefers the current Marathon leader endpoint,
+// falling back to Fallback when the leader is unknown or not among the
+// active members. LeaderFunc is expected to return the leader endpoint as
+// reported by /v2/leader; it is supplied by the caller so this package does
+// not need to know how to issue that request itself.
+type LeaderAffinitySelector struct {
+	// LeaderFunc returns the current Marathon leader endpoint.
+	LeaderFunc func() (string, error)
+	// Fallback is used when LeaderFunc errors or the leader isn't active.
+	Fallback MemberSelector
+}
+
+// Select implements MemberSelector.
+func (s *LeaderAffinitySelector) Select(members []*member) (string, error) {
+	if s.LeaderFunc != nil {
+		if leader, err := s.LeaderFunc(); err == nil {
+			for _, m := range members {
+				if m.endpoint == leader {
+					return m.endpoint, nil
+				}
+			}
+		}
+	}
+	fallback := s.Fallback
+	if fallback == nil {
+		fallback = &firstUpSelector{}
+	}
+	return fallback.Select(members)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstUpSelector(t *testing.T) {
+	members := []*member{{endpoint: "a"}, {endpoint: "b"}}
+	selector := &firstUpSelector{}
+
+	for i := 0; i < 3; i++ {
+		endpoint, err := selector.Select(members)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", endpoint)
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	members := []*member{{endpoint: "a"}, {endpoint: "b"}, {endpoint: "c"}}
+	selector := &roundRobinSelector{}
+
+	var seen []string
+	for i := 0; i < 6; i++ {
+		endpoint, err := selector.Select(members)
+		assert.NoError(t, err)
+		seen = append(seen, endpoint)
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "a", "b", "c"}, seen)
+}
+
+func TestLeastRecentlyUsedSelector(t *testing.T) {
+	members := []*member{
+		{endpoint: "a", lastUsed: time.Now().Add(-1 * time.Minute)},
+		{endpoint: "b", lastUsed: time.Now()},
+	}
+	selector := &leastRecentlyUsedSelector{}
+
+	endpoint, err := selector.Select(members)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", endpoint)
+
+	// "a" was just used, so "b" should now be the least recently used.
+	endpoint, err = selector.Select(members)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", endpoint)
+}
+
+func TestSelectorsErrorOnEmptyMembers(t *testing.T) {
+	selectors := []MemberSelector{
+		&firstUpSelector{},
+		&roundRobinSelector{},
+		&randomSelector{},
+		&leastRecentlyUsedSelector{},
+	}
+
+	for _, selector := range selectors {
+		_, err := selector.Select(nil)
+		assert.Equal(t, ErrMarathonDown, err)
+	}
+}
+
+func TestLeaderAffinitySelector(t *testing.T) {
+	members := []*member{{endpoint: "a"}, {endpoint: "b"}}
+
+	selector := &LeaderAffinitySelector{
+		LeaderFunc: func() (string, error) { return "b", nil },
+	}
+	endpoint, err := selector.Select(members)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", endpoint)
+
+	// Leader not among active members: falls back.
+	selector = &LeaderAffinitySelector{
+		LeaderFunc: func() (string, error) { return "c", nil },
+	}
+	endpoint, err = selector.Select(members)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", endpoint)
+}
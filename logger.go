@@ -0,0 +1,88 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// Logger is a structured, leveled logging interface, modeled on hclog, that
+// can be set on Config.Logger so health-check transitions and retry
+// decisions can be correlated with an application's own logging pipeline
+// instead of going through a single flattened log.Printf line. keyvals is an
+// alternating list of key, value, key, value, ... pairs, e.g.
+// Warn("member marked down", "endpoint", endpoint).
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// nopLogger discards everything; it's the default when clusterOptions.logger
+// is nil.
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keyvals ...interface{}) {}
+func (nopLogger) Info(msg string, keyvals ...interface{})  {}
+func (nopLogger) Warn(msg string, keyvals ...interface{})  {}
+func (nopLogger) Error(msg string, keyvals ...interface{}) {}
+
+// stdLogger adapts the standard library's log.Logger to the Logger
+// interface, rendering keyvals as "key=value" pairs after the message.
+type stdLogger struct {
+	level  string
+	logger *log.Logger
+}
+
+// NewStdLogger returns a Logger backed by the standard library's log
+// package, writing to os.Stderr with the given minimum level
+// ("debug", "info", "warn" or "error").
+func NewStdLogger(minLevel string) Logger {
+	return &stdLogger{
+		level:  minLevel,
+		logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+}
+
+var logLevels = map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+func (l *stdLogger) enabled(level string) bool {
+	min, ok := logLevels[l.level]
+	if !ok {
+		min = 0
+	}
+	return logLevels[level] >= min
+}
+
+func (l *stdLogger) log(level, msg string, keyvals ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	line := "[" + level + "] " + msg
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		line += fmt.Sprintf(" %v=%v", keyvals[i], keyvals[i+1])
+	}
+	l.logger.Println(line)
+}
+
+func (l *stdLogger) Debug(msg string, keyvals ...interface{}) { l.log("debug", msg, keyvals...) }
+func (l *stdLogger) Info(msg string, keyvals ...interface{})  { l.log("info", msg, keyvals...) }
+func (l *stdLogger) Warn(msg string, keyvals ...interface{})  { l.log("warn", msg, keyvals...) }
+func (l *stdLogger) Error(msg string, keyvals ...interface{}) { l.log("error", msg, keyvals...) }
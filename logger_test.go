@@ -0,0 +1,41 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdLoggerRespectsMinLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := &stdLogger{level: "warn", logger: log.New(&buf, "", 0)}
+
+	l.Debug("should be dropped")
+	l.Info("should also be dropped")
+	assert.Empty(t, buf.String())
+
+	l.Warn("endpoint down", "endpoint", "http://10.0.0.1:8080")
+	assert.Contains(t, buf.String(), "[warn] endpoint down endpoint=http://10.0.0.1:8080")
+}
+
+func TestNopLoggerSatisfiesInterface(t *testing.T) {
+	var _ Logger = nopLogger{}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hclog adapts a github.com/hashicorp/go-hclog Logger to
+// marathon.Logger, so it can be set on Config.Logger. It is a separate
+// package so that marathon doesn't force a go-hclog dependency on callers
+// who don't use it.
+package hclog
+
+import (
+	"github.com/SongYintao/go-marathon"
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// adapter wraps an hclog.Logger to satisfy marathon.Logger.
+type adapter struct {
+	logger hclog.Logger
+}
+
+// New returns a marathon.Logger backed by the given hclog.Logger.
+func New(logger hclog.Logger) marathon.Logger {
+	return &adapter{logger: logger}
+}
+
+func (a *adapter) Debug(msg string, keyvals ...interface{}) { a.logger.Debug(msg, keyvals...) }
+func (a *adapter) Info(msg string, keyvals ...interface{})  { a.logger.Info(msg, keyvals...) }
+func (a *adapter) Warn(msg string, keyvals ...interface{})  { a.logger.Warn(msg, keyvals...) }
+func (a *adapter) Error(msg string, keyvals ...interface{}) { a.logger.Error(msg, keyvals...) }
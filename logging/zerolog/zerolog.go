@@ -0,0 +1,51 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package zerolog adapts a github.com/rs/zerolog Logger to marathon.Logger,
+// so it can be set on Config.Logger. It is a separate package so that
+// marathon doesn't force a zerolog dependency on callers who don't use it.
+package zerolog
+
+import (
+	"github.com/SongYintao/go-marathon"
+	"github.com/rs/zerolog"
+)
+
+// adapter wraps a zerolog.Logger to satisfy marathon.Logger.
+type adapter struct {
+	logger zerolog.Logger
+}
+
+// New returns a marathon.Logger backed by the given zerolog.Logger.
+func New(logger zerolog.Logger) marathon.Logger {
+	return &adapter{logger: logger}
+}
+
+func (a *adapter) Debug(msg string, keyvals ...interface{}) { a.log(a.logger.Debug(), msg, keyvals) }
+func (a *adapter) Info(msg string, keyvals ...interface{})  { a.log(a.logger.Info(), msg, keyvals) }
+func (a *adapter) Warn(msg string, keyvals ...interface{})  { a.log(a.logger.Warn(), msg, keyvals) }
+func (a *adapter) Error(msg string, keyvals ...interface{}) { a.log(a.logger.Error(), msg, keyvals) }
+
+func (a *adapter) log(event *zerolog.Event, msg string, keyvals []interface{}) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			continue
+		}
+		event = event.Interface(key, keyvals[i+1])
+	}
+	event.Msg(msg)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMetricsCollector records the member status transitions it observes,
+// for assertions in tests; everything else is a no-op.
+type fakeMetricsCollector struct {
+	noopMetricsCollector
+	sync.Mutex
+	statusChanges []string
+}
+
+func (f *fakeMetricsCollector) MemberStatusChanged(endpoint string, up bool) {
+	f.Lock()
+	defer f.Unlock()
+	state := "down"
+	if up {
+		state = "up"
+	}
+	f.statusChanges = append(f.statusChanges, endpoint+":"+state)
+}
+
+func TestMarkDownReportsMemberStatusChangedOnlyOnThresholdCross(t *testing.T) {
+	metrics := &fakeMetricsCollector{}
+	c, err := newCluster(nil, "http://10.0.0.1:8080", false, clusterOptions{
+		failureThreshold: 2,
+		backoffBase:      time.Hour,
+		backoffMax:       time.Hour,
+		metrics:          metrics,
+	})
+	assert.NoError(t, err)
+	c.members[0].status = memberStatusUp
+
+	// First failure stays under the threshold: no metric, no state change.
+	c.markDown(c.members[0].endpoint)
+	assert.Empty(t, metrics.statusChanges)
+	assert.Equal(t, memberStatusUp, c.members[0].status)
+
+	// Second failure crosses the threshold.
+	c.markDown(c.members[0].endpoint)
+	assert.Equal(t, []string{c.members[0].endpoint + ":down"}, metrics.statusChanges)
+	assert.Equal(t, memberStatusDown, c.members[0].status)
+
+	// The health-check probe is sitting on its (hour-long) backoff timer;
+	// Stop() should terminate it via the done channel without touching the
+	// (nil) HTTP client.
+	c.Stop()
+}
+
+func TestNoopMetricsCollectorSatisfiesInterface(t *testing.T) {
+	var _ MetricsCollector = noopMetricsCollector{}
+}
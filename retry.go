@@ -0,0 +1,143 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the client retries a failed request against the
+// same cluster member before giving up and failing over via markDown.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times a request is issued,
+	// including the first attempt. A value of 1 or a negative value disables
+	// retries; 0 leaves the policy unset, so DefaultRetryPolicy is used.
+	MaxAttempts int
+	// BaseBackoff and MaxBackoff bound the exponential backoff waited
+	// between attempts.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter adds up to 20% random jitter to each computed backoff.
+	Jitter bool
+	// ShouldRetry decides whether a given attempt should be retried. req is
+	// the request that was just issued, res is its response (nil on a
+	// transport-level error), err is any transport-level error. The default
+	// policy only retries idempotent methods (GET/HEAD/PUT/DELETE) on a
+	// transport error or a 429/503 response.
+	ShouldRetry func(req *http.Request, res *http.Response, err error) bool
+}
+
+// DefaultRetryPolicy returns the RetryPolicy used when Config.RetryPolicy
+// is left unset: up to 3 attempts, 250ms base / 5s max backoff with
+// jitter, retrying idempotent methods on transport errors or 429/503.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 250 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		Jitter:      true,
+		ShouldRetry: defaultShouldRetry,
+	}
+}
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+func defaultShouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if !idempotentMethods[req.Method] && !retryAllowed(req.Context()) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable
+}
+
+// backoff returns the delay to wait after the given attempt (1-indexed)
+// fails and before the next one, doubling BaseBackoff each attempt up to
+// MaxBackoff and applying jitter if configured.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseBackoff
+	if base <= 0 {
+		base = DefaultRetryPolicy().BaseBackoff
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = DefaultRetryPolicy().MaxBackoff
+	}
+
+	interval := base
+	for i := 1; i < attempt; i++ {
+		interval *= 2
+		if interval > max {
+			interval = max
+			break
+		}
+	}
+
+	if !p.Jitter || interval <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int63n(int64(interval)/5+1))
+}
+
+// retryContextKey is an unexported type to avoid collisions with context
+// keys from other packages.
+type retryContextKey struct{}
+
+// WithRetry returns a copy of ctx that marks a single non-idempotent
+// request (e.g. a POST) as safe to retry, overriding the default policy's
+// idempotency check for that one call.
+func WithRetry(ctx context.Context, allow bool) context.Context {
+	return context.WithValue(ctx, retryContextKey{}, allow)
+}
+
+// retryAllowed reports whether ctx was tagged via WithRetry.
+func retryAllowed(ctx context.Context) bool {
+	allow, _ := ctx.Value(retryContextKey{}).(bool)
+	return allow
+}
+
+// parseRetryAfter parses the value of a Retry-After response header, which
+// per RFC 7231 is either a number of seconds or an HTTP-date. It returns
+// false if the header is absent or unparseable.
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
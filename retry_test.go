@@ -0,0 +1,231 @@
+/*
+Copyright 2014 Rohith All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCluster builds a cluster in front of one httptest server per handler,
+// with a fast retry policy so these tests don't sleep through real backoffs.
+func testCluster(t *testing.T, policy RetryPolicy, handlers ...http.HandlerFunc) (*cluster, []*httptest.Server) {
+	t.Helper()
+
+	var urls []string
+	var servers []*httptest.Server
+	for _, h := range handlers {
+		ts := httptest.NewServer(h)
+		t.Cleanup(ts.Close)
+		servers = append(servers, ts)
+		urls = append(urls, ts.URL)
+	}
+
+	c, err := newCluster(newHTTPClient(Config{HTTPClient: defaultHTTPClient}), joinURLs(urls), false, clusterOptions{
+		retryPolicy: policy,
+	})
+	require.NoError(t, err)
+	// Keep the health-check probe of a marked-down member from firing (and
+	// hitting its server) while a test is still counting requests.
+	for _, m := range c.members {
+		m.breaker.backoffBase = time.Hour
+		m.breaker.backoffMax = time.Hour
+	}
+	t.Cleanup(c.Stop)
+	return c, servers
+}
+
+func joinURLs(urls []string) string {
+	out := urls[0]
+	for _, u := range urls[1:] {
+		out += "," + u
+	}
+	return out
+}
+
+func TestDefaultShouldRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statusCode   int
+		transportErr error
+		withRetry    bool
+		expect       bool
+	}{
+		{name: "GET on transport error retries", method: http.MethodGet, transportErr: errors.New("boom"), expect: true},
+		{name: "GET on 429 retries", method: http.MethodGet, statusCode: http.StatusTooManyRequests, expect: true},
+		{name: "GET on 503 retries", method: http.MethodGet, statusCode: http.StatusServiceUnavailable, expect: true},
+		{name: "GET on 200 does not retry", method: http.MethodGet, statusCode: http.StatusOK, expect: false},
+		{name: "POST does not retry by default", method: http.MethodPost, statusCode: http.StatusServiceUnavailable, expect: false},
+		{name: "POST retries when opted in via WithRetry", method: http.MethodPost, statusCode: http.StatusServiceUnavailable, withRetry: true, expect: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, "http://marathon/v2/apps", nil)
+			assert.NoError(t, err)
+			if test.withRetry {
+				req = req.WithContext(WithRetry(req.Context(), true))
+			}
+
+			var res *http.Response
+			if test.transportErr == nil {
+				res = &http.Response{StatusCode: test.statusCode}
+			}
+
+			assert.Equal(t, test.expect, defaultShouldRetry(req, res, test.transportErr))
+		})
+	}
+}
+
+func TestRetryPolicyBackoffDoublesUpToMax(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: 350 * time.Millisecond}
+
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 350*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, 350*time.Millisecond, policy.backoff(4))
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: true}
+
+	interval := policy.backoff(1)
+	assert.True(t, interval >= 100*time.Millisecond && interval < 120*time.Millisecond)
+}
+
+func TestParseRetryAfterSeconds(t *testing.T) {
+	d, ok := parseRetryAfter("5")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d)
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	d, ok := parseRetryAfter(future)
+	assert.True(t, ok)
+	assert.True(t, d > 0 && d <= 10*time.Second)
+}
+
+func TestParseRetryAfterInvalid(t *testing.T) {
+	_, ok := parseRetryAfter("not-a-valid-value")
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("-5")
+	assert.False(t, ok)
+}
+
+func TestClusterDoExhaustedRetriesTriggerFailover(t *testing.T) {
+	var failingHits, goodHits int32
+	c, _ := testCluster(t, RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&failingHits, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&goodHits, 1)
+			w.WriteHeader(http.StatusOK)
+		},
+	)
+
+	res, err := c.Do(context.Background(), http.MethodGet, "ping", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&failingHits), "both attempts against the failing member should have been made")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&goodHits))
+	assert.Equal(t, memberStatusDown, c.members[0].status, "member exhausting retries with 503s should be marked down")
+}
+
+func TestClusterDoFailsWhenAllMembersExhausted(t *testing.T) {
+	var hits int32
+	c, _ := testCluster(t, RetryPolicy{MaxAttempts: 1, BaseBackoff: time.Millisecond},
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	)
+
+	res, err := c.Do(context.Background(), http.MethodGet, "ping", nil)
+	assert.Equal(t, ErrMarathonDown, err, "no active member is left once every member is exhausted")
+	assert.Nil(t, res)
+	assert.Equal(t, memberStatusDown, c.members[1].status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestClusterDoRetriesSucceedWithoutFailover(t *testing.T) {
+	var hits int32
+	c, _ := testCluster(t, RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+		func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&hits, 1) < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	)
+
+	res, err := c.Do(context.Background(), http.MethodGet, "ping", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	assert.Equal(t, memberStatusUp, c.members[0].status, "a request that eventually succeeds should not mark the member down")
+}
+
+func TestClusterDoHonorsRetryAfter(t *testing.T) {
+	var hits int32
+	var firstAttempt, secondAttempt time.Time
+	c, _ := testCluster(t, RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Minute},
+		func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&hits, 1) == 1 {
+				firstAttempt = time.Now()
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			secondAttempt = time.Now()
+			w.WriteHeader(http.StatusOK)
+		},
+	)
+
+	res, err := c.Do(context.Background(), http.MethodGet, "ping", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	assert.Less(t, secondAttempt.Sub(firstAttempt), 10*time.Second,
+		"Retry-After: 0 should override the minute-long BaseBackoff")
+}